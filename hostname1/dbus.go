@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"sync"
 
 	"github.com/cloudhousetech/dbus"
 )
@@ -32,24 +33,17 @@ const (
 type Conn struct {
 	conn   *dbus.Conn
 	object dbus.BusObject
+
+	subMu      sync.Mutex
+	subSignals chan *dbus.Signal
+	subDone    chan struct{}
 }
 
 // New() establishes a connection to the system bus and authenticates.
 func New() (*Conn, error) {
-	c := new(Conn)
-
-	if err := c.initConnection(); err != nil {
-		return nil, err
-	}
-
-	return c, nil
-}
-
-func (c *Conn) initConnection() error {
-	var err error
-	c.conn, err = dbus.SystemBusPrivate()
+	conn, err := dbus.SystemBusPrivate()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Only use EXTERNAL method, and hardcode the uid (not username)
@@ -57,25 +51,44 @@ func (c *Conn) initConnection() error {
 	// libc)
 	methods := []dbus.Auth{dbus.AuthExternal(strconv.Itoa(os.Getuid()))}
 
-	err = c.conn.Auth(methods)
-	if err != nil {
-		c.conn.Close()
-		return err
+	if err := conn.Auth(methods); err != nil {
+		conn.Close()
+		return nil, err
 	}
 
-	err = c.conn.Hello()
-	if err != nil {
-		c.conn.Close()
-		return err
+	if err := conn.Hello(); err != nil {
+		conn.Close()
+		return nil, err
 	}
 
-	c.object = c.conn.Object("org.freedesktop.hostname1", dbus.ObjectPath(dbusPath))
+	return NewConn(conn)
+}
+
+// NewConn creates a Conn around an already-authenticated godbus connection,
+// e.g. a session bus, an anonymous-auth bus, or a socket-activated bus
+// obtained from LISTEN_FDS. Unlike New(), NewConn does not set conn up:
+// Auth and Hello must already have been called. Close closes conn.
+func NewConn(conn *dbus.Conn) (*Conn, error) {
+	c := &Conn{conn: conn}
+	c.object = conn.Object("org.freedesktop.hostname1", dbus.ObjectPath(dbusPath))
+
+	return c, nil
+}
 
-	return nil
+// NewWithBusObject creates a Conn directly from a dbus.BusObject, for callers
+// that have already resolved the hostnamed object themselves - for instance
+// against a fakehostnamed service exported on a private bus in tests. Close
+// is a no-op on a Conn constructed this way.
+func NewWithBusObject(obj dbus.BusObject) *Conn {
+	return &Conn{object: obj}
 }
 
 // close the connection to the dbus socket
 func (c *Conn) Close() error {
+	if c.conn == nil {
+		return nil
+	}
+
 	return c.conn.Close()
 }
 
@@ -110,26 +123,30 @@ func (c *Conn) GetProperty(propertyName string) (interface{}, error) {
 	return prop.Value(), nil
 }
 
-// find the dynamic hostname
-func (c *Conn) GetHostname() (string, error) {
-	if hn, err := c.GetProperty("Hostname"); err != nil {
+// stringProperty fetches a single hostnamed property and type-asserts it to
+// a string.
+func (c *Conn) stringProperty(propertyName string) (string, error) {
+	v, err := c.GetProperty(propertyName)
+	if err != nil {
 		return "", err
-	} else if hns, ok := hn.(string); !ok {
-		return "", fmt.Errorf("hostname has incorrect type: %T", hn)
-	} else {
-		return hns, nil
 	}
+
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("%s has incorrect type: %T", propertyName, v)
+	}
+
+	return s, nil
+}
+
+// find the dynamic hostname
+func (c *Conn) GetHostname() (string, error) {
+	return c.stringProperty("Hostname")
 }
 
 // find the static hostname
 func (c *Conn) GetStaticHostname() (string, error) {
-	if hn, err := c.GetProperty("StaticHostname"); err != nil {
-		return "", err
-	} else if hns, ok := hn.(string); !ok {
-		return "", fmt.Errorf("hostname has incorrect type: %T", hn)
-	} else {
-		return hns, nil
-	}
+	return c.stringProperty("StaticHostname")
 }
 
 // SetHostname asks hostnamed to set the hostname.
@@ -156,3 +173,13 @@ func (c *Conn) SetIconName(name string, askForAuth bool) error {
 func (c *Conn) SetChassis(name string, askForAuth bool) error {
 	return c.object.Call(dbusInterface+".SetChassis", 0, name, askForAuth).Err
 }
+
+// SetLocation asks hostnamed to set the location string.
+func (c *Conn) SetLocation(location string, askForAuth bool) error {
+	return c.object.Call(dbusInterface+".SetLocation", 0, location, askForAuth).Err
+}
+
+// SetDeployment asks hostnamed to set the deployment environment.
+func (c *Conn) SetDeployment(deployment string, askForAuth bool) error {
+	return c.object.Call(dbusInterface+".SetDeployment", 0, deployment, askForAuth).Err
+}
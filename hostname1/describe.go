@@ -0,0 +1,178 @@
+// Copyright 2015 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hostname1
+
+import (
+	"encoding/json"
+
+	"github.com/cloudhousetech/dbus"
+)
+
+// dbusErrUnknownMethod is returned by godbus when the remote object has no
+// such method, which is how we detect a systemd old enough to not have
+// Describe.
+const dbusErrUnknownMethod = "org.freedesktop.DBus.Error.UnknownMethod"
+
+// HostInfo is the full set of host identity fields reported by hostnamed's
+// Describe method. See
+// http://www.freedesktop.org/wiki/Software/systemd/hostnamed/
+type HostInfo struct {
+	Hostname                  string `json:"Hostname"`
+	StaticHostname            string `json:"StaticHostname"`
+	PrettyHostname            string `json:"PrettyHostname"`
+	IconName                  string `json:"IconName"`
+	Chassis                   string `json:"Chassis"`
+	Deployment                string `json:"Deployment"`
+	Location                  string `json:"Location"`
+	KernelName                string `json:"KernelName"`
+	KernelRelease             string `json:"KernelRelease"`
+	KernelVersion             string `json:"KernelVersion"`
+	OperatingSystemPrettyName string `json:"OperatingSystemPrettyName"`
+	OperatingSystemCPEName    string `json:"OperatingSystemCPEName"`
+	HomeURL                   string `json:"HomeURL"`
+	HardwareVendor            string `json:"HardwareVendor"`
+	HardwareModel             string `json:"HardwareModel"`
+	FirmwareVersion           string `json:"FirmwareVersion"`
+	MachineID                 string `json:"MachineID"`
+	BootID                    string `json:"BootID"`
+}
+
+// Describe returns every host identity field hostnamed knows about. On
+// systemd versions that predate the Describe method, it falls back to
+// assembling a HostInfo from GetProperties instead.
+func (c *Conn) Describe() (*HostInfo, error) {
+	var blob string
+
+	err := c.object.Call(dbusInterface+".Describe", 0).Store(&blob)
+	if err != nil {
+		if dbusErr, ok := err.(dbus.Error); ok && dbusErr.Name == dbusErrUnknownMethod {
+			return c.describeFromProperties()
+		}
+		return nil, err
+	}
+
+	info := new(HostInfo)
+	if err := json.Unmarshal([]byte(blob), info); err != nil {
+		return nil, err
+	}
+
+	return info, nil
+}
+
+func (c *Conn) describeFromProperties() (*HostInfo, error) {
+	props, err := c.GetProperties()
+	if err != nil {
+		return nil, err
+	}
+
+	info := new(HostInfo)
+	for name, v := range props {
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+
+		switch name {
+		case "Hostname":
+			info.Hostname = s
+		case "StaticHostname":
+			info.StaticHostname = s
+		case "PrettyHostname":
+			info.PrettyHostname = s
+		case "IconName":
+			info.IconName = s
+		case "Chassis":
+			info.Chassis = s
+		case "Deployment":
+			info.Deployment = s
+		case "Location":
+			info.Location = s
+		case "KernelName":
+			info.KernelName = s
+		case "KernelRelease":
+			info.KernelRelease = s
+		case "KernelVersion":
+			info.KernelVersion = s
+		case "OperatingSystemPrettyName":
+			info.OperatingSystemPrettyName = s
+		case "OperatingSystemCPEName":
+			info.OperatingSystemCPEName = s
+		case "HomeURL":
+			info.HomeURL = s
+		case "HardwareVendor":
+			info.HardwareVendor = s
+		case "HardwareModel":
+			info.HardwareModel = s
+		case "FirmwareVersion":
+			info.FirmwareVersion = s
+		case "MachineID":
+			info.MachineID = s
+		case "BootID":
+			info.BootID = s
+		}
+	}
+
+	return info, nil
+}
+
+// GetIconName returns the configured icon name.
+func (c *Conn) GetIconName() (string, error) {
+	return c.stringProperty("IconName")
+}
+
+// GetChassis returns the configured chassis type.
+func (c *Conn) GetChassis() (string, error) {
+	return c.stringProperty("Chassis")
+}
+
+// GetDeployment returns the configured deployment environment.
+func (c *Conn) GetDeployment() (string, error) {
+	return c.stringProperty("Deployment")
+}
+
+// GetLocation returns the configured location string.
+func (c *Conn) GetLocation() (string, error) {
+	return c.stringProperty("Location")
+}
+
+// GetKernelName returns the kernel name, e.g. "Linux".
+func (c *Conn) GetKernelName() (string, error) {
+	return c.stringProperty("KernelName")
+}
+
+// GetKernelRelease returns the kernel release, e.g. "5.10.0-generic".
+func (c *Conn) GetKernelRelease() (string, error) {
+	return c.stringProperty("KernelRelease")
+}
+
+// GetOperatingSystemPrettyName returns PRETTY_NAME from os-release.
+func (c *Conn) GetOperatingSystemPrettyName() (string, error) {
+	return c.stringProperty("OperatingSystemPrettyName")
+}
+
+// GetHomeURL returns HOME_URL from os-release.
+func (c *Conn) GetHomeURL() (string, error) {
+	return c.stringProperty("HomeURL")
+}
+
+// GetMachineID returns the machine ID.
+func (c *Conn) GetMachineID() (string, error) {
+	return c.stringProperty("MachineID")
+}
+
+// GetBootID returns the current boot ID.
+func (c *Conn) GetBootID() (string, error) {
+	return c.stringProperty("BootID")
+}
@@ -0,0 +1,157 @@
+// Copyright 2015 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fakehostnamed implements a minimal org.freedesktop.hostname1
+// service that can be exported on a private or session bus, so downstream
+// projects can unit-test their hostname1.Conn code without root privileges
+// or a real systemd-hostnamed running.
+package fakehostnamed
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/cloudhousetech/dbus"
+)
+
+const (
+	dbusInterface = "org.freedesktop.hostname1"
+	dbusPath      = "/org/freedesktop/hostname1"
+)
+
+// Server is an in-process implementation of org.freedesktop.hostname1.
+type Server struct {
+	conn *dbus.Conn
+
+	mu    sync.Mutex
+	props map[string]string
+}
+
+// New exports a Server of org.freedesktop.hostname1 on conn and returns it.
+// conn must already be authenticated (Auth and Hello called); a private bus
+// from dbus.SessionBusPrivate, or one set up with dbustest-style anonymous
+// auth, both work.
+func New(conn *dbus.Conn) (*Server, error) {
+	s := &Server{
+		conn: conn,
+		props: map[string]string{
+			"Hostname":       "fakehost",
+			"StaticHostname": "fakehost",
+		},
+	}
+
+	if err := conn.Export(s, dbus.ObjectPath(dbusPath), dbusInterface); err != nil {
+		return nil, err
+	}
+
+	if err := conn.Export(s, dbus.ObjectPath(dbusPath), "org.freedesktop.DBus.Properties"); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// SetHostname implements org.freedesktop.hostname1.SetHostname.
+func (s *Server) SetHostname(name string, askForAuth bool) *dbus.Error {
+	s.set("Hostname", name)
+	return nil
+}
+
+// SetStaticHostname implements org.freedesktop.hostname1.SetStaticHostname.
+func (s *Server) SetStaticHostname(name string, askForAuth bool) *dbus.Error {
+	s.set("StaticHostname", name)
+	return nil
+}
+
+// SetPrettyHostname implements org.freedesktop.hostname1.SetPrettyHostname.
+func (s *Server) SetPrettyHostname(name string, askForAuth bool) *dbus.Error {
+	s.set("PrettyHostname", name)
+	return nil
+}
+
+// SetIconName implements org.freedesktop.hostname1.SetIconName.
+func (s *Server) SetIconName(name string, askForAuth bool) *dbus.Error {
+	s.set("IconName", name)
+	return nil
+}
+
+// SetChassis implements org.freedesktop.hostname1.SetChassis.
+func (s *Server) SetChassis(name string, askForAuth bool) *dbus.Error {
+	s.set("Chassis", name)
+	return nil
+}
+
+// SetLocation implements org.freedesktop.hostname1.SetLocation.
+func (s *Server) SetLocation(location string, askForAuth bool) *dbus.Error {
+	s.set("Location", location)
+	return nil
+}
+
+// SetDeployment implements org.freedesktop.hostname1.SetDeployment.
+func (s *Server) SetDeployment(deployment string, askForAuth bool) *dbus.Error {
+	s.set("Deployment", deployment)
+	return nil
+}
+
+// Describe implements org.freedesktop.hostname1.Describe.
+func (s *Server) Describe() (string, *dbus.Error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	blob, err := json.Marshal(s.props)
+	if err != nil {
+		return "", dbus.NewError("org.freedesktop.DBus.Error.Failed", []interface{}{err.Error()})
+	}
+
+	return string(blob), nil
+}
+
+// GetAll implements org.freedesktop.DBus.Properties.GetAll.
+func (s *Server) GetAll(iface string) (map[string]dbus.Variant, *dbus.Error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make(map[string]dbus.Variant, len(s.props))
+	for k, v := range s.props {
+		out[k] = dbus.MakeVariant(v)
+	}
+
+	return out, nil
+}
+
+// Get implements org.freedesktop.DBus.Properties.Get.
+func (s *Server) Get(iface, name string) (dbus.Variant, *dbus.Error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return dbus.MakeVariant(s.props[name]), nil
+}
+
+// SetProperty sets a property directly, bypassing the setter methods, and
+// emits a PropertiesChanged signal so tests can exercise Conn.Subscribe
+// end-to-end.
+func (s *Server) SetProperty(name, value string) error {
+	s.set(name, value)
+	return nil
+}
+
+func (s *Server) set(name, value string) {
+	s.mu.Lock()
+	s.props[name] = value
+	s.mu.Unlock()
+
+	changed := map[string]dbus.Variant{name: dbus.MakeVariant(value)}
+	s.conn.Emit(dbus.ObjectPath(dbusPath), "org.freedesktop.DBus.Properties.PropertiesChanged",
+		dbusInterface, changed, []string{})
+}
@@ -0,0 +1,123 @@
+// Copyright 2015 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hostname1
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cloudhousetech/dbus"
+
+	"github.com/ScriptRock/go-systemd/hostname1/fakehostnamed"
+)
+
+// dialTestBus opens a private connection to the session bus and claims the
+// org.freedesktop.hostname1 name on it, so a fakehostnamed.Server exported
+// there is reachable the same way a real hostnamed would be. Tests skip
+// rather than fail when no session bus is available, since that's an
+// environment limitation, not a code defect.
+func dialTestBus(t *testing.T) *dbus.Conn {
+	t.Helper()
+
+	conn, err := dbus.SessionBusPrivate()
+	if err != nil {
+		t.Skipf("no session bus available: %v", err)
+	}
+
+	if err := conn.Auth(nil); err != nil {
+		conn.Close()
+		t.Skipf("session bus auth failed: %v", err)
+	}
+
+	if err := conn.Hello(); err != nil {
+		conn.Close()
+		t.Skipf("session bus hello failed: %v", err)
+	}
+
+	if _, err := conn.RequestName(dbusInterface, dbus.NameFlagReplaceExisting); err != nil {
+		conn.Close()
+		t.Skipf("could not own %s on test bus: %v", dbusInterface, err)
+	}
+
+	return conn
+}
+
+// TestRoundTrip drives a fakehostnamed.Server through hostname1.Conn end to
+// end: setters, GetProperty-backed getters, Describe, and Subscribe.
+func TestRoundTrip(t *testing.T) {
+	conn := dialTestBus(t)
+	defer conn.Close()
+
+	if _, err := fakehostnamed.New(conn); err != nil {
+		t.Fatalf("fakehostnamed.New: %v", err)
+	}
+
+	c, err := NewConn(conn)
+	if err != nil {
+		t.Fatalf("NewConn: %v", err)
+	}
+	defer c.Close()
+
+	if err := c.SetStaticHostname("example", false); err != nil {
+		t.Fatalf("SetStaticHostname: %v", err)
+	}
+
+	if hn, err := c.GetStaticHostname(); err != nil {
+		t.Fatalf("GetStaticHostname: %v", err)
+	} else if hn != "example" {
+		t.Fatalf("GetStaticHostname = %q, want %q", hn, "example")
+	}
+
+	info, err := c.Describe()
+	if err != nil {
+		t.Fatalf("Describe: %v", err)
+	}
+	if info.StaticHostname != "example" {
+		t.Fatalf("Describe().StaticHostname = %q, want %q", info.StaticHostname, "example")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	changes, err := c.Subscribe(ctx)
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	defer c.Unsubscribe()
+
+	if err := c.SetHostname("renamed", false); err != nil {
+		t.Fatalf("SetHostname: %v", err)
+	}
+
+	select {
+	case change := <-changes:
+		if change.Hostname != "renamed" {
+			t.Fatalf("change.Hostname = %q, want %q", change.Hostname, "renamed")
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for a PropertiesChanged signal")
+	}
+}
+
+// TestSubscribeRequiresBusConnection guards against the nil-conn panic that
+// NewWithBusObject previously left reachable through Subscribe.
+func TestSubscribeRequiresBusConnection(t *testing.T) {
+	c := NewWithBusObject(nil)
+
+	if _, err := c.Subscribe(context.Background()); err == nil {
+		t.Fatal("Subscribe on a NewWithBusObject Conn should return an error, got nil")
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright 2015 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hostname1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/cloudhousetech/dbus"
+)
+
+// subscriptionBacklog is the size of the HostnameChange channel Subscribe
+// returns. It is buffered, and full buffers drop the oldest pending event,
+// because this fork's godbus delivers signals with a blocking send held
+// under its own dispatch lock (conn.go) - a consumer that stalls reading an
+// unbuffered channel would wedge signal delivery for the whole connection,
+// not just this subscription.
+const subscriptionBacklog = 16
+
+// HostnameChange is a snapshot of the hostnamed properties that changed, as
+// reported by a single org.freedesktop.DBus.Properties.PropertiesChanged
+// signal.
+type HostnameChange struct {
+	Hostname       string
+	StaticHostname string
+	PrettyHostname string
+	IconName       string
+	Chassis        string
+	Deployment     string
+	Location       string
+}
+
+const propertiesChangedRule = "type='signal',interface='org.freedesktop.DBus.Properties',member='PropertiesChanged',path='" + dbusPath + "'"
+
+// Subscribe registers a match rule for PropertiesChanged signals emitted by
+// hostnamed and returns a channel of HostnameChange events, so callers can
+// react to e.g. `hostnamectl set-hostname` invocations from other processes
+// without polling GetProperties. The returned channel is buffered
+// (subscriptionBacklog entries); if a caller falls behind, the oldest
+// undelivered event is dropped to make room rather than blocking. The
+// channel is closed when ctx is cancelled or Unsubscribe is called; only one
+// subscription may be active on a Conn at a time, and Subscribe requires a
+// Conn built with New or NewConn (one with an actual bus connection, not
+// NewWithBusObject).
+func (c *Conn) Subscribe(ctx context.Context) (<-chan HostnameChange, error) {
+	if c.conn == nil {
+		return nil, errors.New("hostname1: Subscribe requires a bus connection")
+	}
+
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+
+	if c.subSignals != nil {
+		return nil, fmt.Errorf("hostname1: already subscribed")
+	}
+
+	if err := c.conn.BusObject().Call("org.freedesktop.DBus.AddMatch", 0, propertiesChangedRule).Err; err != nil {
+		return nil, err
+	}
+
+	signals := make(chan *dbus.Signal, 16)
+	c.conn.Signal(signals)
+	c.subSignals = signals
+	c.subDone = make(chan struct{})
+
+	out := make(chan HostnameChange, subscriptionBacklog)
+	go c.runSubscription(ctx, signals, c.subDone, out)
+
+	return out, nil
+}
+
+func (c *Conn) runSubscription(ctx context.Context, signals chan *dbus.Signal, done chan struct{}, out chan HostnameChange) {
+	defer close(out)
+
+	for {
+		select {
+		case <-ctx.Done():
+			c.Unsubscribe()
+			return
+		case <-done:
+			return
+		case sig, ok := <-signals:
+			if !ok {
+				return
+			}
+
+			change, ok := parseHostnameChange(sig)
+			if !ok {
+				continue
+			}
+
+			deliver(out, change)
+		}
+	}
+}
+
+// deliver sends change on out without blocking. If out's buffer is full, the
+// oldest pending event is dropped to make room - see subscriptionBacklog for
+// why Subscribe can never let this send block.
+func deliver(out chan HostnameChange, change HostnameChange) {
+	for {
+		select {
+		case out <- change:
+			return
+		default:
+		}
+
+		select {
+		case <-out:
+		default:
+		}
+	}
+}
+
+func parseHostnameChange(sig *dbus.Signal) (HostnameChange, bool) {
+	if sig.Name != "org.freedesktop.DBus.Properties.PropertiesChanged" || len(sig.Body) < 2 {
+		return HostnameChange{}, false
+	}
+
+	iface, ok := sig.Body[0].(string)
+	if !ok || iface != dbusInterface {
+		return HostnameChange{}, false
+	}
+
+	changed, ok := sig.Body[1].(map[string]dbus.Variant)
+	if !ok {
+		return HostnameChange{}, false
+	}
+
+	var change HostnameChange
+	for name, v := range changed {
+		s, _ := v.Value().(string)
+		switch name {
+		case "Hostname":
+			change.Hostname = s
+		case "StaticHostname":
+			change.StaticHostname = s
+		case "PrettyHostname":
+			change.PrettyHostname = s
+		case "IconName":
+			change.IconName = s
+		case "Chassis":
+			change.Chassis = s
+		case "Deployment":
+			change.Deployment = s
+		case "Location":
+			change.Location = s
+		}
+	}
+
+	return change, true
+}
+
+// Unsubscribe removes the match rule installed by Subscribe and closes the
+// channel it returned. It is a no-op if there is no active subscription, and
+// safe to call more than once.
+func (c *Conn) Unsubscribe() error {
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+
+	if c.subSignals == nil {
+		return nil
+	}
+
+	c.conn.RemoveSignal(c.subSignals)
+	close(c.subSignals)
+	c.subSignals = nil
+
+	close(c.subDone)
+	c.subDone = nil
+
+	return c.conn.BusObject().Call("org.freedesktop.DBus.RemoveMatch", 0, propertiesChangedRule).Err
+}